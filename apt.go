@@ -21,10 +21,9 @@ package apt
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
-	"os"
 	"os/exec"
-	"path"
 	"regexp"
 	"slices"
 	"strconv"
@@ -89,8 +88,7 @@ func parseDpkgQueryOutput(out []byte) []*Package {
 // CheckForUpdates runs an apt update to retrieve new packages available
 // from the repositories
 func CheckForUpdates() (output []byte, err error) {
-	cmd := exec.Command("apt-get", "update", "-q")
-	return cmd.CombinedOutput()
+	return drainProgress(CheckForUpdatesWithContext(context.Background()))
 }
 
 // ListUpgradable return all the upgradable packages and the version that
@@ -128,15 +126,10 @@ func ListUpgradable() ([]*Package, error) {
 
 // Upgrade runs the upgrade for a set of packages
 func Upgrade(packs ...*Package) (output []byte, err error) {
-	args := []string{"upgrade", "-y"}
-	for _, pack := range packs {
-		if pack == nil || pack.Name == "" {
-			return nil, fmt.Errorf("apt.Upgrade: Invalid package with empty Name")
-		}
-		args = append(args, pack.Name)
-	}
-	cmd := exec.Command("apt-get", args...)
-	return cmd.CombinedOutput()
+	pre := maybeSnapshot("/etc/apt")
+	output, err = drainProgress(UpgradeWithContext(context.Background(), packs...))
+	recordTransaction(ActionUpgrade, packageNames(packs), pre, "", "", output, err)
+	return output, err
 }
 
 // UpgradeAll upgrade all upgradable packages
@@ -147,8 +140,7 @@ func UpgradeAll() (output []byte, err error) {
 
 // DistUpgrade upgrades all upgradable packages, it may remove older versions to install newer ones.
 func DistUpgrade() (output []byte, err error) {
-	cmd := exec.Command("apt-get", "dist-upgrade", "-y")
-	return cmd.CombinedOutput()
+	return drainProgress(DistUpgradeWithContext(context.Background()))
 }
 
 // Remove removes a set of packages
@@ -160,21 +152,19 @@ func Remove(packs ...*Package) (output []byte, err error) {
 		}
 		args = append(args, pack.Name)
 	}
+	pre := maybeSnapshot("/etc/apt")
 	cmd := exec.Command("apt-get", args...)
-	return cmd.CombinedOutput()
+	output, err = cmd.CombinedOutput()
+	recordTransaction(ActionRemove, packageNames(packs), pre, "", "", output, err)
+	return output, err
 }
 
 // Install installs a set of packages
 func Install(packs ...*Package) (output []byte, err error) {
-	args := []string{"install", "-y"}
-	for _, pack := range packs {
-		if pack == nil || pack.Name == "" {
-			return nil, fmt.Errorf("apt.Install: Invalid package with empty Name")
-		}
-		args = append(args, pack.Name)
-	}
-	cmd := exec.Command("apt-get", args...)
-	return cmd.CombinedOutput()
+	pre := maybeSnapshot("/etc/apt")
+	output, err = drainProgress(InstallWithContext(context.Background(), packs...))
+	recordTransaction(ActionInstall, packageNames(packs), pre, "", "", output, err)
+	return output, err
 }
 
 // Install tries to install a set of packages
@@ -193,22 +183,7 @@ func InstallDry(packs ...*Package) (output []byte, err error) {
 // Download triest to downlaod a set of packages
 // targetPath should be absolute.
 func Download(pack *Package, targetPath string) (output []byte, err error) {
-	args := []string{"install", "-y", "--reinstall", "--download-only",
-		"-o", "Debug::NoLocking=1",
-		"-o", fmt.Sprintf("Dir::Cache::archives=\"%s\"", targetPath),
-	}
-	if pack == nil || pack.Name == "" {
-		return nil, fmt.Errorf("apt.Download: Invalid package with empty Name")
-	}
-	// This is to resolve an issue with apt not always creating a "partial"
-	// directory in the target cache directory. SMH.
-	err = os.MkdirAll(path.Join(targetPath, "partial"), 0755)
-	if err != nil {
-		return nil, err
-	}
-	args = append(args, pack.Name)
-	cmd := exec.Command("apt-get", args...)
-	return cmd.CombinedOutput()
+	return drainProgress(DownloadWithContext(context.Background(), pack, targetPath))
 }
 
 // Get a list of dependencies, from the bottom up.
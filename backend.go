@@ -0,0 +1,172 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import "os/exec"
+
+// Backend is implemented by every supported package manager. The default
+// Backend, AptBackend, keeps the exact semantics of the top-level
+// functions in this package (apt-get/apt/dpkg-query/apt-cache); the other
+// implementations adapt the same operations to their native tools, so
+// callers that only need the common subset can write distro-agnostic
+// code against this interface instead of against a specific backend.
+type Backend interface {
+	// Name returns the backend identifier, e.g. "apt", "dnf", "pacman".
+	Name() string
+	List() ([]*Package, error)
+	Search(pattern string) ([]*Package, error)
+	Install(packs ...*Package) (output []byte, err error)
+	Remove(packs ...*Package) (output []byte, err error)
+	Upgrade(packs ...*Package) (output []byte, err error)
+	Download(pack *Package, targetPath string) (output []byte, err error)
+	Dependencies(pack *Package) ([]string, error)
+	Repositories(configFolderPath string) (RepositoryList, error)
+}
+
+// AptBackend is the Backend implementation for Debian/Ubuntu-style
+// systems. It simply delegates to the package-level APT functions.
+type AptBackend struct{}
+
+// Name implements Backend.
+func (AptBackend) Name() string { return "apt" }
+
+// List implements Backend.
+func (AptBackend) List() ([]*Package, error) { return List() }
+
+// Search implements Backend.
+func (AptBackend) Search(pattern string) ([]*Package, error) { return Search(pattern) }
+
+// Install implements Backend.
+func (AptBackend) Install(packs ...*Package) ([]byte, error) { return Install(packs...) }
+
+// Remove implements Backend.
+func (AptBackend) Remove(packs ...*Package) ([]byte, error) { return Remove(packs...) }
+
+// Upgrade implements Backend.
+func (AptBackend) Upgrade(packs ...*Package) ([]byte, error) { return Upgrade(packs...) }
+
+// Download implements Backend.
+func (AptBackend) Download(pack *Package, targetPath string) ([]byte, error) {
+	return Download(pack, targetPath)
+}
+
+// Dependencies implements Backend.
+func (AptBackend) Dependencies(pack *Package) ([]string, error) { return GetDependencies(pack) }
+
+// Repositories implements Backend.
+func (AptBackend) Repositories(configFolderPath string) (RepositoryList, error) {
+	return ParseAPTConfigFolder(configFolderPath)
+}
+
+// detectors lists the known backends in priority order together with the
+// binary that must be on PATH for that backend to be selected.
+var detectors = []struct {
+	binary  string
+	backend Backend
+}{
+	{"apt-get", AptBackend{}},
+	{"dnf", DnfBackend{}},
+	{"yum", DnfBackend{binary: "yum"}},
+	{"pacman", PacmanBackend{}},
+	{"zypper", ZypperBackend{}},
+	{"apk", ApkBackend{}},
+}
+
+// DetectBackend inspects the running system's PATH and returns the
+// Backend matching the first package manager binary it finds. It falls
+// back to AptBackend if none of the known binaries are present.
+func DetectBackend() Backend {
+	for _, d := range detectors {
+		if _, err := exec.LookPath(d.binary); err == nil {
+			return d.backend
+		}
+	}
+	return AptBackend{}
+}
+
+// currentBackend is the Backend used by the distro-agnostic helpers
+// below. It is initialized lazily from DetectBackend on first use, so
+// importing this package never runs exec.LookPath as a side effect.
+var currentBackend Backend
+
+// CurrentBackend returns the Backend currently selected for
+// distro-agnostic operations, detecting one via DetectBackend the first
+// time it is called.
+func CurrentBackend() Backend {
+	if currentBackend == nil {
+		currentBackend = DetectBackend()
+	}
+	return currentBackend
+}
+
+// SetBackend overrides the Backend returned by CurrentBackend. It is
+// mainly useful for tests and for callers that want to force a specific
+// package manager regardless of what DetectBackend would pick.
+func SetBackend(b Backend) {
+	currentBackend = b
+}
+
+// The functions below are the actual distro-agnostic entry points: they
+// delegate through CurrentBackend() instead of hard-coding apt-get, so
+// code written against them works unchanged on dnf/pacman/zypper/apk
+// systems. List, Search, Install, Remove, Upgrade, Download and
+// GetDependencies remain apt-specific and untouched, so existing callers
+// keep their current APT semantics.
+
+// ListPackages lists installed packages via CurrentBackend().
+func ListPackages() ([]*Package, error) {
+	return CurrentBackend().List()
+}
+
+// SearchPackages searches for packages matching pattern via
+// CurrentBackend().
+func SearchPackages(pattern string) ([]*Package, error) {
+	return CurrentBackend().Search(pattern)
+}
+
+// InstallPackages installs packs via CurrentBackend().
+func InstallPackages(packs ...*Package) (output []byte, err error) {
+	return CurrentBackend().Install(packs...)
+}
+
+// RemovePackages removes packs via CurrentBackend().
+func RemovePackages(packs ...*Package) (output []byte, err error) {
+	return CurrentBackend().Remove(packs...)
+}
+
+// UpgradePackages upgrades packs via CurrentBackend().
+func UpgradePackages(packs ...*Package) (output []byte, err error) {
+	return CurrentBackend().Upgrade(packs...)
+}
+
+// DownloadPackage downloads pack into targetPath via CurrentBackend().
+func DownloadPackage(pack *Package, targetPath string) (output []byte, err error) {
+	return CurrentBackend().Download(pack, targetPath)
+}
+
+// PackageDependencies returns pack's dependencies via CurrentBackend().
+func PackageDependencies(pack *Package) ([]string, error) {
+	return CurrentBackend().Dependencies(pack)
+}
+
+// ConfiguredRepositories returns the repositories configured under
+// configFolderPath via CurrentBackend().
+func ConfiguredRepositories(configFolderPath string) (RepositoryList, error) {
+	return CurrentBackend().Repositories(configFolderPath)
+}
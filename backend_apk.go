@@ -0,0 +1,173 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+)
+
+// ApkBackend is the Backend implementation for Alpine-style systems.
+type ApkBackend struct{}
+
+// Name implements Backend.
+func (ApkBackend) Name() string { return "apk" }
+
+// List implements Backend.
+func (ApkBackend) List() ([]*Package, error) {
+	out, err := exec.Command("apk", "info", "-v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running apk info -v: %s", err)
+	}
+	return parseApkInfoOutput(out), nil
+}
+
+// parseApkInfoOutput parses `apk info -v` lines of the form
+// "name-version-release". The name itself may contain dashes, so
+// only the last two dash-separated fields are treated as version-release.
+func parseApkInfoOutput(out []byte) []*Package {
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		nameVersion := scanner.Text()
+		idx := strings.LastIndex(nameVersion, "-")
+		if idx <= 0 {
+			continue
+		}
+		parts := strings.Split(nameVersion, "-")
+		if len(parts) < 3 {
+			continue
+		}
+		name := strings.Join(parts[:len(parts)-2], "-")
+		version := strings.Join(parts[len(parts)-2:], "-")
+		res = append(res, &Package{Name: name, Version: version, Status: "installed"})
+	}
+	return res
+}
+
+// Search implements Backend.
+func (ApkBackend) Search(pattern string) ([]*Package, error) {
+	out, err := exec.Command("apk", "search", pattern).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running apk search: %s", err)
+	}
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		res = append(res, &Package{Name: strings.TrimSpace(scanner.Text())})
+	}
+	return res, nil
+}
+
+// Install implements Backend.
+func (ApkBackend) Install(packs ...*Package) ([]byte, error) {
+	args := []string{"add"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("apk.Install: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("apk", args...).CombinedOutput()
+}
+
+// Remove implements Backend.
+func (ApkBackend) Remove(packs ...*Package) ([]byte, error) {
+	args := []string{"del"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("apk.Remove: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("apk", args...).CombinedOutput()
+}
+
+// Upgrade implements Backend. With no packages given it performs a full
+// system upgrade, matching `apk upgrade`.
+func (ApkBackend) Upgrade(packs ...*Package) ([]byte, error) {
+	if len(packs) == 0 {
+		return exec.Command("apk", "upgrade").CombinedOutput()
+	}
+	args := []string{"add", "-u"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("apk.Upgrade: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("apk", args...).CombinedOutput()
+}
+
+// Download implements Backend.
+func (ApkBackend) Download(pack *Package, targetPath string) ([]byte, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("apk.Download: Invalid package with empty Name")
+	}
+	args := []string{"fetch", "-o", targetPath, pack.Name}
+	return exec.Command("apk", args...).CombinedOutput()
+}
+
+// Dependencies implements Backend.
+func (ApkBackend) Dependencies(pack *Package) ([]string, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("apk.Dependencies: Invalid package with empty Name")
+	}
+	out, err := exec.Command("apk", "info", "-R", pack.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running apk info -R: %s", err)
+	}
+	res := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		dep := strings.TrimSpace(scanner.Text())
+		if dep == "" || strings.HasSuffix(dep, ":") {
+			continue
+		}
+		res = append(res, dep)
+	}
+	return res, nil
+}
+
+// Repositories implements Backend. Alpine keeps its repository list as a
+// flat newline-separated file rather than a folder of stanzas, so
+// configFolderPath is expected to point directly to /etc/apk/repositories.
+func (ApkBackend) Repositories(configFolderPath string) (RepositoryList, error) {
+	data, err := ioutil.ReadFile(configFolderPath)
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", configFolderPath, err)
+	}
+	res := RepositoryList{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		res = append(res, &Repository{
+			Enabled: !strings.HasPrefix(line, "#"),
+			URI:     strings.TrimPrefix(line, "#"),
+		})
+	}
+	return res, nil
+}
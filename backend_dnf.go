@@ -0,0 +1,178 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DnfBackend is the Backend implementation for Fedora/RHEL-style systems.
+// It shells out to rpm to list installed packages and their dependencies,
+// and to dnf (or yum, for older systems) for everything else.
+type DnfBackend struct {
+	// binary is the command used for install/remove/upgrade operations.
+	// It defaults to "dnf" and can be set to "yum" for older distros.
+	binary string
+}
+
+func (b DnfBackend) command() string {
+	if b.binary == "" {
+		return "dnf"
+	}
+	return b.binary
+}
+
+// Name implements Backend.
+func (b DnfBackend) Name() string { return b.command() }
+
+// List implements Backend.
+func (b DnfBackend) List() ([]*Package, error) {
+	cmd := exec.Command("rpm", "-qa", "--queryformat",
+		"%{NAME}\t%{ARCH}\t%{VERSION}-%{RELEASE}\t%{SUMMARY}\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running rpm -qa: %s", err)
+	}
+	return parseRPMQueryOutput(out), nil
+}
+
+// Search implements Backend.
+func (b DnfBackend) Search(pattern string) ([]*Package, error) {
+	cmd := exec.Command(b.command(), "search", "-q", pattern)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s search: %s", b.command(), err)
+	}
+	return parseDnfSearchOutput(out), nil
+}
+
+// parseDnfSearchOutput parses "name.arch : summary" lines from `dnf
+// search`, skipping the "Last metadata..." and "=== Name Matched ==="
+// header lines that don't contain a " : " separator.
+func parseDnfSearchOutput(out []byte) []*Package {
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		name, summary, ok := strings.Cut(scanner.Text(), " : ")
+		if !ok {
+			continue
+		}
+		res = append(res, &Package{
+			Name:             strings.TrimSuffix(strings.TrimSpace(name), ".arch"),
+			ShortDescription: strings.TrimSpace(summary),
+		})
+	}
+	return res
+}
+
+// Install implements Backend.
+func (b DnfBackend) Install(packs ...*Package) ([]byte, error) {
+	args := []string{"install", "-y"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("%s.Install: Invalid package with empty Name", b.command())
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command(b.command(), args...).CombinedOutput()
+}
+
+// Remove implements Backend.
+func (b DnfBackend) Remove(packs ...*Package) ([]byte, error) {
+	args := []string{"remove", "-y"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("%s.Remove: Invalid package with empty Name", b.command())
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command(b.command(), args...).CombinedOutput()
+}
+
+// Upgrade implements Backend.
+func (b DnfBackend) Upgrade(packs ...*Package) ([]byte, error) {
+	args := []string{"upgrade", "-y"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("%s.Upgrade: Invalid package with empty Name", b.command())
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command(b.command(), args...).CombinedOutput()
+}
+
+// Download implements Backend.
+func (b DnfBackend) Download(pack *Package, targetPath string) ([]byte, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("%s.Download: Invalid package with empty Name", b.command())
+	}
+	args := []string{"download", "--destdir", targetPath, pack.Name}
+	return exec.Command(b.command(), args...).CombinedOutput()
+}
+
+// Dependencies implements Backend.
+func (b DnfBackend) Dependencies(pack *Package) ([]string, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("%s.Dependencies: Invalid package with empty Name", b.command())
+	}
+	out, err := exec.Command("rpm", "-qR", pack.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running rpm -qR: %s", err)
+	}
+	res := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		dep := strings.TrimSpace(scanner.Text())
+		if dep != "" {
+			res = append(res, dep)
+		}
+	}
+	return res, nil
+}
+
+// Repositories implements Backend. It reads /etc/yum.repos.d/*.repo (or
+// whatever configFolderPath points to) and maps each "[section]" block to
+// a Repository, using URI for baseurl and Comment for the name= value
+// (or the section name when a block has none).
+func (b DnfBackend) Repositories(configFolderPath string) (RepositoryList, error) {
+	return parseINIStyleRepoFolder(configFolderPath, ".repo", "baseurl", "enabled", "name")
+}
+
+func parseRPMQueryOutput(out []byte) []*Package {
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		data := strings.Split(scanner.Text(), "\t")
+		if len(data) < 4 {
+			continue
+		}
+		res = append(res, &Package{
+			Name:             data[0],
+			Architecture:     data[1],
+			Version:          data[2],
+			Status:           "installed",
+			ShortDescription: data[3],
+		})
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// parseINIStyleRepoFolder is shared by the backends whose repository
+// configuration is a folder of INI-style files (dnf's *.repo, zypper's
+// *.repo). Each "[section]" block becomes one Repository: uriKey supplies
+// Repository.URI, enabledKey toggles Repository.Enabled (missing or "1"
+// means enabled) and nameKey, if present, is stored in Repository.Comment.
+func parseINIStyleRepoFolder(folderPath, suffix, uriKey, enabledKey, nameKey string) (RepositoryList, error) {
+	list, err := ioutil.ReadDir(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s folder: %s", folderPath, err)
+	}
+
+	res := RepositoryList{}
+	for _, l := range list {
+		if !strings.HasSuffix(l.Name(), suffix) {
+			continue
+		}
+		repos, err := parseINIStyleRepoFile(filepath.Join(folderPath, l.Name()), uriKey, enabledKey, nameKey)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing %s: %s", l.Name(), err)
+		}
+		res = append(res, repos...)
+	}
+	return res, nil
+}
+
+func parseINIStyleRepoFile(path, uriKey, enabledKey, nameKey string) (RepositoryList, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	res := RepositoryList{}
+	var current *Repository
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			if current != nil {
+				res = append(res, current)
+			}
+			current = &Repository{Enabled: true, Comment: strings.Trim(line, "[]")}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch {
+		case key == uriKey:
+			current.URI = value
+		case key == enabledKey:
+			current.Enabled = value != "0" && !strings.EqualFold(value, "false")
+		case key == nameKey:
+			current.Comment = value
+		}
+	}
+	if current != nil {
+		res = append(res, current)
+	}
+	return res, nil
+}
@@ -0,0 +1,162 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PacmanBackend is the Backend implementation for Arch-style systems.
+type PacmanBackend struct{}
+
+// Name implements Backend.
+func (PacmanBackend) Name() string { return "pacman" }
+
+// List implements Backend.
+func (PacmanBackend) List() ([]*Package, error) {
+	out, err := exec.Command("pacman", "-Q").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running pacman -Q: %s", err)
+	}
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		res = append(res, &Package{Name: fields[0], Version: fields[1], Status: "installed"})
+	}
+	return res, nil
+}
+
+// Search implements Backend.
+func (PacmanBackend) Search(pattern string) ([]*Package, error) {
+	out, err := exec.Command("pacman", "-Ss", pattern).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running pacman -Ss: %s", err)
+	}
+	return parsePacmanSearchOutput(out), nil
+}
+
+// parsePacmanSearchOutput parses `pacman -Ss` output, which interleaves
+// a "repo/name version" line for each match with an indented description
+// line that is discarded.
+func parsePacmanSearchOutput(out []byte) []*Package {
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, " ") {
+			// Description line for the previous package; skip it.
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		_, name, ok := strings.Cut(fields[0], "/")
+		if !ok {
+			name = fields[0]
+		}
+		res = append(res, &Package{Name: name, Version: fields[1]})
+	}
+	return res
+}
+
+// Install implements Backend.
+func (PacmanBackend) Install(packs ...*Package) ([]byte, error) {
+	args := []string{"-S", "--noconfirm"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("pacman.Install: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("pacman", args...).CombinedOutput()
+}
+
+// Remove implements Backend.
+func (PacmanBackend) Remove(packs ...*Package) ([]byte, error) {
+	args := []string{"-R", "--noconfirm"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("pacman.Remove: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("pacman", args...).CombinedOutput()
+}
+
+// Upgrade implements Backend. With no packages given it performs a full
+// system upgrade, matching `pacman -Syu`; with packages given it
+// reinstalls/updates just those.
+func (PacmanBackend) Upgrade(packs ...*Package) ([]byte, error) {
+	if len(packs) == 0 {
+		return exec.Command("pacman", "-Syu", "--noconfirm").CombinedOutput()
+	}
+	args := []string{"-S", "--noconfirm"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("pacman.Upgrade: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("pacman", args...).CombinedOutput()
+}
+
+// Download implements Backend.
+func (PacmanBackend) Download(pack *Package, targetPath string) ([]byte, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("pacman.Download: Invalid package with empty Name")
+	}
+	args := []string{"-Sw", "--noconfirm", "--cachedir", targetPath, pack.Name}
+	return exec.Command("pacman", args...).CombinedOutput()
+}
+
+// Dependencies implements Backend.
+func (PacmanBackend) Dependencies(pack *Package) ([]string, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("pacman.Dependencies: Invalid package with empty Name")
+	}
+	out, err := exec.Command("pactree", "-u", pack.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running pactree: %s", err)
+	}
+	res := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		dep := strings.TrimLeft(scanner.Text(), " |`-")
+		if dep != "" && dep != pack.Name {
+			res = append(res, dep)
+		}
+	}
+	return res, nil
+}
+
+// Repositories implements Backend. Arch keeps all of its repository
+// definitions in a single pacman.conf file rather than a folder, so
+// configFolderPath is expected to be the path to that file.
+func (PacmanBackend) Repositories(configFolderPath string) (RepositoryList, error) {
+	return parseINIStyleRepoFile(configFolderPath, "Server", "", "")
+}
@@ -0,0 +1,175 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const rpmQueryFixture = "curl\tx86_64\t7.88.1-10\tCommand line tool for transferring data\n" +
+	"bash\tx86_64\t5.2.15-2\tGNU Bourne Again SHell\n" +
+	"malformed line with no tabs\n"
+
+func TestParseRPMQueryOutput(t *testing.T) {
+	packs := parseRPMQueryOutput([]byte(rpmQueryFixture))
+	if len(packs) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packs), packs)
+	}
+	if packs[0].Name != "curl" || packs[0].Architecture != "x86_64" || packs[0].Version != "7.88.1-10" ||
+		packs[0].Status != "installed" || packs[0].ShortDescription != "Command line tool for transferring data" {
+		t.Errorf("unexpected first package: %+v", packs[0])
+	}
+	if packs[1].Name != "bash" || packs[1].Version != "5.2.15-2" {
+		t.Errorf("unexpected second package: %+v", packs[1])
+	}
+}
+
+const dnfSearchFixture = `Last metadata expiration check: 0:12:34 ago.
+========================== Name Matched: curl ==========================
+curl.x86_64 : Command line tool for transferring data
+curl-minimal.x86_64 : A minimal version of curl
+`
+
+func TestParseDnfSearchOutput(t *testing.T) {
+	packs := parseDnfSearchOutput([]byte(dnfSearchFixture))
+	if len(packs) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packs), packs)
+	}
+	if packs[0].Name != "curl.x86_64" || packs[0].ShortDescription != "Command line tool for transferring data" {
+		t.Errorf("unexpected first package: %+v", packs[0])
+	}
+	if packs[1].Name != "curl-minimal.x86_64" {
+		t.Errorf("unexpected second package: %+v", packs[1])
+	}
+}
+
+const pacmanSearchFixture = `extra/curl 8.7.1-1
+    command line tool and library for transferring data
+core/bash 5.2.026-1
+    The GNU Bourne Again shell
+`
+
+func TestParsePacmanSearchOutput(t *testing.T) {
+	packs := parsePacmanSearchOutput([]byte(pacmanSearchFixture))
+	if len(packs) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packs), packs)
+	}
+	if packs[0].Name != "curl" || packs[0].Version != "8.7.1-1" {
+		t.Errorf("unexpected first package: %+v", packs[0])
+	}
+	if packs[1].Name != "bash" || packs[1].Version != "5.2.026-1" {
+		t.Errorf("unexpected second package: %+v", packs[1])
+	}
+}
+
+const zypperSearchFixture = `S  | Name       | Summary                                  | Type
+---+------------+------------------------------------------+--------
+i  | curl       | Command line tool for transferring data   | package
+   | curl-devel | Development files for curl                | package
+`
+
+func TestParseZypperSearchOutput(t *testing.T) {
+	packs := parseZypperSearchOutput([]byte(zypperSearchFixture))
+	if len(packs) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packs), packs)
+	}
+	if packs[0].Name != "curl" || packs[0].ShortDescription != "Command line tool for transferring data" {
+		t.Errorf("unexpected first package: %+v", packs[0])
+	}
+	if packs[1].Name != "curl-devel" {
+		t.Errorf("unexpected second package: %+v", packs[1])
+	}
+}
+
+const apkInfoFixture = `curl-8.7.1-r0
+musl-1.2.4-r2
+no-version
+`
+
+func TestParseApkInfoOutput(t *testing.T) {
+	packs := parseApkInfoOutput([]byte(apkInfoFixture))
+	if len(packs) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packs), packs)
+	}
+	if packs[0].Name != "curl" || packs[0].Version != "8.7.1-r0" || packs[0].Status != "installed" {
+		t.Errorf("unexpected first package: %+v", packs[0])
+	}
+	if packs[1].Name != "musl" || packs[1].Version != "1.2.4-r2" {
+		t.Errorf("unexpected second package: %+v", packs[1])
+	}
+}
+
+const iniRepoFixture = `[fedora]
+name=Fedora $releasever - $basearch
+baseurl=https://dl.fedoraproject.org/pub/fedora/linux/releases/$releasever/Everything/$basearch/os/
+enabled=1
+
+[fedora-disabled]
+name=Fedora disabled test repo
+baseurl=https://example.com/disabled/
+enabled=0
+`
+
+func TestParseINIStyleRepoFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fedora.repo")
+	if err := os.WriteFile(path, []byte(iniRepoFixture), 0644); err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+
+	repos, err := parseINIStyleRepoFile(path, "baseurl", "enabled", "name")
+	if err != nil {
+		t.Fatalf("parseINIStyleRepoFile: %s", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repositories, got %d: %+v", len(repos), repos)
+	}
+	if repos[0].URI != "https://dl.fedoraproject.org/pub/fedora/linux/releases/$releasever/Everything/$basearch/os/" {
+		t.Errorf("unexpected URI: %q", repos[0].URI)
+	}
+	if !repos[0].Enabled {
+		t.Errorf("expected first repository enabled")
+	}
+	if repos[0].Comment != "Fedora $releasever - $basearch" {
+		t.Errorf("unexpected Comment: %q", repos[0].Comment)
+	}
+	if repos[1].Enabled {
+		t.Errorf("expected second repository disabled")
+	}
+}
+
+func TestParseINIStyleRepoFolder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fedora.repo"), []byte(iniRepoFixture), 0644); err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("not a repo file"), 0644); err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+
+	repos, err := parseINIStyleRepoFolder(dir, ".repo", "baseurl", "enabled", "name")
+	if err != nil {
+		t.Fatalf("parseINIStyleRepoFolder: %s", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repositories from fedora.repo, got %d: %+v", len(repos), repos)
+	}
+}
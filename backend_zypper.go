@@ -0,0 +1,151 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ZypperBackend is the Backend implementation for openSUSE/SLE systems.
+type ZypperBackend struct{}
+
+// Name implements Backend.
+func (ZypperBackend) Name() string { return "zypper" }
+
+// List implements Backend.
+func (ZypperBackend) List() ([]*Package, error) {
+	out, err := exec.Command("rpm", "-qa", "--queryformat",
+		"%{NAME}\t%{ARCH}\t%{VERSION}-%{RELEASE}\t%{SUMMARY}\n").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running rpm -qa: %s", err)
+	}
+	return parseRPMQueryOutput(out), nil
+}
+
+// Search implements Backend.
+func (ZypperBackend) Search(pattern string) ([]*Package, error) {
+	out, err := exec.Command("zypper", "--non-interactive", "search", pattern).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running zypper search: %s", err)
+	}
+	return parseZypperSearchOutput(out), nil
+}
+
+// parseZypperSearchOutput parses `zypper search` rows, which look like
+// "i  | name | summary | package". The header row and the "---+---"
+// separator row both have fewer than 3 "|"-delimited fields once their
+// first column is accounted for, except the header, which is filtered
+// out by name below since "Name" is never a real package name.
+func parseZypperSearchOutput(out []byte) []*Package {
+	res := []*Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 3 {
+			continue
+		}
+		name := strings.TrimSpace(fields[1])
+		if name == "" || name == "Name" {
+			continue
+		}
+		res = append(res, &Package{
+			Name:             name,
+			ShortDescription: strings.TrimSpace(fields[2]),
+		})
+	}
+	return res
+}
+
+// Install implements Backend.
+func (ZypperBackend) Install(packs ...*Package) ([]byte, error) {
+	args := []string{"--non-interactive", "install"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("zypper.Install: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("zypper", args...).CombinedOutput()
+}
+
+// Remove implements Backend.
+func (ZypperBackend) Remove(packs ...*Package) ([]byte, error) {
+	args := []string{"--non-interactive", "remove"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("zypper.Remove: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("zypper", args...).CombinedOutput()
+}
+
+// Upgrade implements Backend. With no packages given it performs a full
+// system upgrade, matching `zypper update`.
+func (ZypperBackend) Upgrade(packs ...*Package) ([]byte, error) {
+	if len(packs) == 0 {
+		return exec.Command("zypper", "--non-interactive", "update").CombinedOutput()
+	}
+	args := []string{"--non-interactive", "update"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return nil, fmt.Errorf("zypper.Upgrade: Invalid package with empty Name")
+		}
+		args = append(args, pack.Name)
+	}
+	return exec.Command("zypper", args...).CombinedOutput()
+}
+
+// Download implements Backend.
+func (ZypperBackend) Download(pack *Package, targetPath string) ([]byte, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("zypper.Download: Invalid package with empty Name")
+	}
+	args := []string{"--non-interactive", "--pkg-cache-dir", targetPath, "download", pack.Name}
+	return exec.Command("zypper", args...).CombinedOutput()
+}
+
+// Dependencies implements Backend.
+func (ZypperBackend) Dependencies(pack *Package) ([]string, error) {
+	if pack == nil || pack.Name == "" {
+		return nil, fmt.Errorf("zypper.Dependencies: Invalid package with empty Name")
+	}
+	out, err := exec.Command("rpm", "-qR", pack.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running rpm -qR: %s", err)
+	}
+	res := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		dep := strings.TrimSpace(scanner.Text())
+		if dep != "" {
+			res = append(res, dep)
+		}
+	}
+	return res, nil
+}
+
+// Repositories implements Backend.
+func (ZypperBackend) Repositories(configFolderPath string) (RepositoryList, error) {
+	return parseINIStyleRepoFolder(configFolderPath, ".repo", "baseurl", "enabled", "name")
+}
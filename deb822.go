@@ -0,0 +1,223 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RepositoryFormat identifies the on-disk representation of a Repository.
+type RepositoryFormat string
+
+const (
+	// FormatOneLine is the classic "deb URI distribution components"
+	// sources.list syntax. It is the zero value of RepositoryFormat.
+	FormatOneLine RepositoryFormat = ""
+	// FormatDEB822 is the multi-line "Types:/URIs:/Suites:/..." stanza
+	// syntax used by *.sources files.
+	FormatDEB822 RepositoryFormat = "deb822"
+)
+
+// deb822FieldOrder controls the key order used when writing a stanza, to
+// keep output stable and readable.
+var deb822FieldOrder = []string{
+	"Types", "URIs", "Suites", "Components", "Signed-By", "Architectures", "Enabled",
+}
+
+func parseDEB822File(configPath string) (RepositoryList, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", configPath, err)
+	}
+	return parseDEB822Stanzas(data)
+}
+
+// parseDEB822Stanzas parses the content of a DEB822 *.sources file into a
+// RepositoryList. Each blank-line-separated stanza becomes one Repository.
+// Continuation lines (starting with a space or tab) are folded into the
+// value of the preceding key, as per the RFC822 field folding rules.
+func parseDEB822Stanzas(data []byte) (RepositoryList, error) {
+	res := RepositoryList{}
+
+	fields := map[string]string{}
+	lastKey := ""
+	flush := func() error {
+		if len(fields) == 0 {
+			return nil
+		}
+		repo, err := deb822StanzaToRepository(fields)
+		if err != nil {
+			return err
+		}
+		res = append(res, repo)
+		fields = map[string]string{}
+		lastKey = ""
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			// DEB822Stanza writes Comment as a single trailing "# comment"
+			// line after the stanza's fields, so only a "#" line seen after
+			// at least one field is kept as the stanza's Comment; this
+			// round-trips what DEB822Stanza writes without misattributing
+			// a file-level header comment (seen before any field) to the
+			// first stanza.
+			if lastKey != "" {
+				fields["Comment"] = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			}
+			continue
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && lastKey != "" {
+			fields[lastKey] = strings.TrimSpace(fields[lastKey] + " " + trimmed)
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		fields[key] = strings.TrimSpace(value)
+		lastKey = key
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func deb822StanzaToRepository(fields map[string]string) (*Repository, error) {
+	r := &Repository{
+		Format:        FormatDEB822,
+		Enabled:       true,
+		Types:         splitFields(fields["Types"]),
+		URIs:          splitFields(fields["URIs"]),
+		Suites:        splitFields(fields["Suites"]),
+		ComponentList: splitFields(fields["Components"]),
+		SignedBy:      fields["Signed-By"],
+		Architectures: splitFields(fields["Architectures"]),
+		Comment:       fields["Comment"],
+	}
+	if enabled, ok := fields["Enabled"]; ok {
+		r.Enabled = !strings.EqualFold(enabled, "no")
+	}
+
+	// Keep the legacy singular fields populated from the lists (the first
+	// type, URI and suite, and all components space-separated), so code
+	// written against the one-line API still sees a sensible (if partial)
+	// view of a DEB822 repository.
+	if len(r.Types) > 0 {
+		r.SourceRepo = r.Types[0] == "deb-src"
+	}
+	if len(r.URIs) > 0 {
+		r.URI = r.URIs[0]
+	}
+	if len(r.Suites) > 0 {
+		r.Distribution = r.Suites[0]
+	}
+	r.Components = strings.Join(r.ComponentList, " ")
+	if r.SignedBy != "" {
+		r.Options = "signed-by=" + r.SignedBy
+	}
+
+	return r, nil
+}
+
+// DEB822Stanza renders the Repository as a DEB822 stanza suitable for a
+// *.sources file. If set, Comment is written as a trailing "# comment"
+// line, which parseDEB822Stanzas reads back into Comment so the two
+// round-trip losslessly.
+func (r *Repository) DEB822Stanza() string {
+	values := map[string]string{
+		"Types":         strings.Join(r.Types, " "),
+		"URIs":          strings.Join(r.URIs, " "),
+		"Suites":        strings.Join(r.Suites, " "),
+		"Components":    strings.Join(r.ComponentList, " "),
+		"Signed-By":     r.SignedBy,
+		"Architectures": strings.Join(r.Architectures, " "),
+	}
+	if !r.Enabled {
+		values["Enabled"] = "no"
+	}
+
+	var b strings.Builder
+	for _, key := range deb822FieldOrder {
+		value := values[key]
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", key, value)
+	}
+	if strings.TrimSpace(r.Comment) != "" {
+		fmt.Fprintf(&b, "# %s\n", r.Comment)
+	}
+	return b.String()
+}
+
+func addRepositoryDEB822(repo *Repository, configFolderPath string) error {
+	managedPath := filepath.Join(configFolderPath, "sources.list.d", "managed.sources")
+	f, err := os.OpenFile(managedPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if os.IsNotExist(err) {
+		f, err = os.OpenFile(managedPath, os.O_CREATE|os.O_WRONLY, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("Opening %s: %s", managedPath, err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(repo.DEB822Stanza() + "\n"); err != nil {
+		return fmt.Errorf("Writing repo data to config file %s: %s", managedPath, err)
+	}
+	return nil
+}
+
+func splitFields(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Fields(value)
+}
+
+func slicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
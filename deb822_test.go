@@ -0,0 +1,126 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import "testing"
+
+const deb822Fixture = `Types: deb deb-src
+URIs: https://deb.example.com/debian https://mirror.example.com/debian
+Suites: stable stable-updates
+Components: main contrib
+Signed-By: /etc/apt/keyrings/example.gpg
+Architectures: amd64 arm64
+
+Types: deb
+URIs: https://disabled.example.com/debian
+Suites: stable
+Components: main
+Enabled: no
+`
+
+func TestParseDEB822Stanzas(t *testing.T) {
+	repos, err := parseDEB822Stanzas([]byte(deb822Fixture))
+	if err != nil {
+		t.Fatalf("parseDEB822Stanzas: %s", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 stanzas, got %d: %+v", len(repos), repos)
+	}
+
+	first := repos[0]
+	if first.Format != FormatDEB822 {
+		t.Errorf("expected FormatDEB822, got %q", first.Format)
+	}
+	if !first.Enabled {
+		t.Errorf("expected first stanza enabled")
+	}
+	if !slicesEqual(first.Types, []string{"deb", "deb-src"}) {
+		t.Errorf("unexpected Types: %+v", first.Types)
+	}
+	if !slicesEqual(first.URIs, []string{"https://deb.example.com/debian", "https://mirror.example.com/debian"}) {
+		t.Errorf("unexpected URIs: %+v", first.URIs)
+	}
+	if !slicesEqual(first.Suites, []string{"stable", "stable-updates"}) {
+		t.Errorf("unexpected Suites: %+v", first.Suites)
+	}
+	if !slicesEqual(first.ComponentList, []string{"main", "contrib"}) {
+		t.Errorf("unexpected Components: %+v", first.ComponentList)
+	}
+	if first.SignedBy != "/etc/apt/keyrings/example.gpg" {
+		t.Errorf("unexpected SignedBy: %q", first.SignedBy)
+	}
+	if !slicesEqual(first.Architectures, []string{"amd64", "arm64"}) {
+		t.Errorf("unexpected Architectures: %+v", first.Architectures)
+	}
+
+	second := repos[1]
+	if second.Enabled {
+		t.Errorf("expected second stanza disabled")
+	}
+}
+
+func TestDEB822StanzaRoundTrip(t *testing.T) {
+	repo := &Repository{
+		Format:        FormatDEB822,
+		Enabled:       true,
+		Types:         []string{"deb", "deb-src"},
+		URIs:          []string{"https://deb.example.com/debian", "https://mirror.example.com/debian"},
+		Suites:        []string{"stable", "stable-updates"},
+		ComponentList: []string{"main", "contrib"},
+		SignedBy:      "/etc/apt/keyrings/example.gpg",
+		Architectures: []string{"amd64", "arm64"},
+		Comment:       "added by go-apt-client",
+	}
+
+	stanza := repo.DEB822Stanza()
+	roundTripped, err := parseDEB822Stanzas([]byte(stanza))
+	if err != nil {
+		t.Fatalf("parseDEB822Stanzas: %s", err)
+	}
+	if len(roundTripped) != 1 {
+		t.Fatalf("expected 1 stanza, got %d: %+v", len(roundTripped), roundTripped)
+	}
+	if !repo.Equals(roundTripped[0]) {
+		t.Errorf("round trip not equal: original %+v, got %+v", repo, roundTripped[0])
+	}
+	// Equals doesn't compare Comment (it's metadata, not identity), so
+	// check it separately: it must survive the round trip too.
+	if roundTripped[0].Comment != repo.Comment {
+		t.Errorf("expected Comment to round-trip, got %q, want %q", roundTripped[0].Comment, repo.Comment)
+	}
+}
+
+func TestParseDEB822StanzasIgnoresHeaderComment(t *testing.T) {
+	const withHeader = `# Managed by go-apt-client, do not edit
+Types: deb
+URIs: https://deb.example.com/debian
+Suites: stable
+Components: main
+`
+	repos, err := parseDEB822Stanzas([]byte(withHeader))
+	if err != nil {
+		t.Fatalf("parseDEB822Stanzas: %s", err)
+	}
+	if len(repos) != 1 {
+		t.Fatalf("expected 1 stanza, got %d: %+v", len(repos), repos)
+	}
+	if repos[0].Comment != "" {
+		t.Errorf("expected a comment preceding any field not to be attributed to the stanza, got %q", repos[0].Comment)
+	}
+}
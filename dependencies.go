@@ -0,0 +1,294 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DependencyKind distinguishes the relation an apt-cache depends edge
+// represents.
+type DependencyKind string
+
+// The dependency kinds understood by apt-cache depends.
+const (
+	KindDepends    DependencyKind = "Depends"
+	KindPreDepends DependencyKind = "PreDepends"
+	KindRecommends DependencyKind = "Recommends"
+	KindSuggests   DependencyKind = "Suggests"
+	KindConflicts  DependencyKind = "Conflicts"
+	KindBreaks     DependencyKind = "Breaks"
+	KindReplaces   DependencyKind = "Replaces"
+)
+
+// DependencyAlternative is one member of an OR-group: a dependency can be
+// satisfied by any one of its alternatives. Virtual is true when
+// apt-cache printed the name in angle brackets, meaning it is a virtual
+// package or one not known to the local cache rather than a concrete,
+// installable package.
+type DependencyAlternative struct {
+	Package *Package
+	Virtual bool
+}
+
+// DependencyEdge records that From requires one of Alternatives to
+// satisfy a relation of the given Kind.
+type DependencyEdge struct {
+	From         *Package
+	Kind         DependencyKind
+	Alternatives []*DependencyAlternative
+}
+
+// DependencyGraph is the full dependency graph of a package, as reported
+// by apt-cache depends. Nodes is keyed by package name so the same
+// *Package is reused across edges that reference it.
+type DependencyGraph struct {
+	Root  *Package
+	Nodes map[string]*Package
+	Edges []*DependencyEdge
+}
+
+func (g *DependencyGraph) nodeFor(name string) *Package {
+	if pack, ok := g.Nodes[name]; ok {
+		return pack
+	}
+	pack := &Package{Name: name}
+	g.Nodes[name] = pack
+	return pack
+}
+
+// DependencyGraphOptions controls which relations BuildDependencyGraph
+// asks apt-cache to include.
+type DependencyGraphOptions struct {
+	// Recurse also resolves the dependencies of every dependency,
+	// building the full transitive graph instead of just pkg's direct
+	// relations.
+	Recurse bool
+	// IncludeRecommends/IncludeSuggests additionally ask for Recommends
+	// and Suggests relations, which apt-cache omits by default.
+	IncludeRecommends bool
+	IncludeSuggests   bool
+}
+
+// BuildDependencyGraph builds the DependencyGraph of pkg using
+// apt-cache depends.
+func BuildDependencyGraph(pkg *Package, opts DependencyGraphOptions) (*DependencyGraph, error) {
+	if pkg == nil || pkg.Name == "" {
+		return nil, fmt.Errorf("apt.BuildDependencyGraph: Invalid package with empty Name")
+	}
+
+	args := []string{"depends"}
+	if opts.Recurse {
+		args = append(args, "--recurse")
+	}
+	if opts.IncludeRecommends {
+		args = append(args, "--recommends")
+	}
+	if opts.IncludeSuggests {
+		args = append(args, "--suggests")
+	}
+	args = append(args, pkg.Name)
+
+	out, err := exec.Command("apt-cache", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running apt-cache depends: %s", err)
+	}
+	return parseDependsOutput(pkg.Name, out), nil
+}
+
+// ReverseDependencies returns the packages that directly depend on pkg,
+// as reported by apt-cache rdepends.
+func ReverseDependencies(pkg *Package) ([]*Package, error) {
+	if pkg == nil || pkg.Name == "" {
+		return nil, fmt.Errorf("apt.ReverseDependencies: Invalid package with empty Name")
+	}
+
+	out, err := exec.Command("apt-cache", "rdepends", pkg.Name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("running apt-cache rdepends: %s", err)
+	}
+	return parseRDependsOutput(out), nil
+}
+
+// TopologicalSort orders g's packages so that every package appears after
+// all of its Depends/PreDepends prerequisites (Conflicts/Breaks/Replaces
+// edges are ignored, since they don't constrain install order). For an
+// OR-group, every alternative is treated as a prerequisite, which is
+// conservative but always safe regardless of which alternative ends up
+// chosen at install time. It returns an error if the graph has a cycle.
+func TopologicalSort(g *DependencyGraph) ([]*Package, error) {
+	if g == nil {
+		return nil, fmt.Errorf("apt.TopologicalSort: Invalid nil graph")
+	}
+
+	dependents := map[string][]string{}
+	inDegree := make(map[string]int, len(g.Nodes))
+	for name := range g.Nodes {
+		inDegree[name] = 0
+	}
+	for _, edge := range g.Edges {
+		if !isOrderingKind(edge.Kind) {
+			continue
+		}
+		for _, alt := range edge.Alternatives {
+			dependents[alt.Package.Name] = append(dependents[alt.Package.Name], edge.From.Name)
+			inDegree[edge.From.Name]++
+		}
+	}
+
+	queue := []string{}
+	for name, deg := range inDegree {
+		if deg == 0 {
+			queue = append(queue, name)
+		}
+	}
+	sort.Strings(queue)
+
+	order := make([]*Package, 0, len(g.Nodes))
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		order = append(order, g.Nodes[name])
+
+		next := append([]string{}, dependents[name]...)
+		sort.Strings(next)
+		for _, d := range next {
+			inDegree[d]--
+			if inDegree[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+		sort.Strings(queue)
+	}
+
+	if len(order) != len(g.Nodes) {
+		return nil, fmt.Errorf("apt.TopologicalSort: dependency cycle detected")
+	}
+	return order, nil
+}
+
+func isOrderingKind(kind DependencyKind) bool {
+	return kind == KindDepends || kind == KindPreDepends
+}
+
+// dependsLineRegexp matches one indented relation line of apt-cache
+// depends output, e.g. "  Depends: libc6 (>= 2.17)" or
+// " |Depends: <udev>".
+var dependsLineRegexp = regexp.MustCompile(`^(\s*\|?)\s*(Depends|PreDepends|Recommends|Suggests|Conflicts|Breaks|Replaces):\s*(.+)$`)
+
+func parseDependsOutput(root string, out []byte) *DependencyGraph {
+	g := &DependencyGraph{Nodes: map[string]*Package{}}
+	g.Root = g.nodeFor(root)
+
+	current := root
+	var group []*DependencyAlternative
+	var groupKind DependencyKind
+
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		g.Edges = append(g.Edges, &DependencyEdge{
+			From:         g.nodeFor(current),
+			Kind:         groupKind,
+			Alternatives: group,
+		})
+		group = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			flush()
+			current = strings.TrimSpace(line)
+			g.nodeFor(current)
+			continue
+		}
+
+		m := dependsLineRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		isAlternative := strings.Contains(m[1], "|")
+		kind := DependencyKind(m[2])
+		name, virtual := parseDependencyToken(m[3])
+
+		alt := &DependencyAlternative{Package: g.nodeFor(name), Virtual: virtual}
+		group = append(group, alt)
+		groupKind = kind
+		if !isAlternative {
+			flush()
+		}
+	}
+	flush()
+
+	return g
+}
+
+func parseDependencyToken(token string) (name string, virtual bool) {
+	name = strings.TrimSpace(token)
+	if idx := strings.IndexAny(name, " ("); idx >= 0 {
+		name = name[:idx]
+	}
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		return strings.TrimSuffix(strings.TrimPrefix(name, "<"), ">"), true
+	}
+	return name, false
+}
+
+func parseRDependsOutput(out []byte) []*Package {
+	res := []*Package{}
+	seen := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	lineNum := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+		// The first two lines are the queried package name and the
+		// "Reverse Depends:" header; everything after is one reverse
+		// dependency per line, optionally "|"-prefixed and/or carrying a
+		// version constraint in parentheses.
+		if lineNum <= 2 {
+			continue
+		}
+		name := strings.TrimSpace(line)
+		name = strings.TrimPrefix(name, "|")
+		name, _ = parseDependencyToken(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		res = append(res, &Package{Name: name})
+	}
+	return res
+}
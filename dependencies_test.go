@@ -0,0 +1,137 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import "testing"
+
+// These fixtures are hand-written in the layout of apt-cache depends and
+// rdepends output and exercise its formatting quirks (leading "|"
+// alternatives, "<virtual>" names, version constraints in parentheses);
+// they are not recorded from a live system.
+const dependsFixture = `curl
+  Depends: libc6
+  Depends: libcurl4
+ |Depends: ca-certificates
+  Depends: <ca-certs>
+  Recommends: ca-certificates
+libc6
+  PreDepends: libgcc-s1
+  Conflicts: <prelink>
+libcurl4
+  Depends: libc6
+  Depends: libssl3
+`
+
+func TestParseDependsOutput(t *testing.T) {
+	g := parseDependsOutput("curl", []byte(dependsFixture))
+
+	if g.Root.Name != "curl" {
+		t.Fatalf("expected root curl, got %q", g.Root.Name)
+	}
+	if _, ok := g.Nodes["libssl3"]; !ok {
+		t.Fatalf("expected libssl3 to be a node, got %+v", g.Nodes)
+	}
+
+	var orEdge *DependencyEdge
+	for _, e := range g.Edges {
+		if e.From.Name == "curl" && e.Kind == KindDepends && len(e.Alternatives) > 1 {
+			orEdge = e
+		}
+	}
+	if orEdge == nil {
+		t.Fatalf("expected an OR-group Depends edge from curl, got %+v", g.Edges)
+	}
+	if len(orEdge.Alternatives) != 2 {
+		t.Fatalf("expected 2 alternatives, got %d: %+v", len(orEdge.Alternatives), orEdge.Alternatives)
+	}
+	if orEdge.Alternatives[0].Package.Name != "ca-certificates" {
+		t.Errorf("expected first alternative ca-certificates, got %q", orEdge.Alternatives[0].Package.Name)
+	}
+	if !orEdge.Alternatives[1].Virtual || orEdge.Alternatives[1].Package.Name != "ca-certs" {
+		t.Errorf("expected second alternative to be virtual ca-certs, got %+v", orEdge.Alternatives[1])
+	}
+
+	var conflict *DependencyEdge
+	for _, e := range g.Edges {
+		if e.From.Name == "libc6" && e.Kind == KindConflicts {
+			conflict = e
+		}
+	}
+	if conflict == nil || !conflict.Alternatives[0].Virtual {
+		t.Fatalf("expected a virtual Conflicts edge from libc6, got %+v", conflict)
+	}
+}
+
+const rdependsFixture = `curl
+Reverse Depends:
+  wget2
+  |apt-transport-https
+  some-tool (>= 1.0)
+`
+
+func TestParseRDependsOutput(t *testing.T) {
+	res := parseRDependsOutput([]byte(rdependsFixture))
+
+	want := []string{"wget2", "apt-transport-https", "some-tool"}
+	if len(res) != len(want) {
+		t.Fatalf("expected %d reverse dependencies, got %d: %+v", len(want), len(res), res)
+	}
+	for i, name := range want {
+		if res[i].Name != name {
+			t.Errorf("position %d: expected %q, got %q", i, name, res[i].Name)
+		}
+	}
+}
+
+func TestTopologicalSort(t *testing.T) {
+	g := parseDependsOutput("curl", []byte(dependsFixture))
+
+	order, err := TopologicalSort(g)
+	if err != nil {
+		t.Fatalf("TopologicalSort: %s", err)
+	}
+
+	pos := map[string]int{}
+	for i, pack := range order {
+		pos[pack.Name] = i
+	}
+	if pos["libc6"] >= pos["curl"] {
+		t.Errorf("expected libc6 before curl, got order %+v", order)
+	}
+	if pos["libssl3"] >= pos["libcurl4"] {
+		t.Errorf("expected libssl3 before libcurl4, got order %+v", order)
+	}
+}
+
+func TestTopologicalSortDetectsCycle(t *testing.T) {
+	a := &Package{Name: "a"}
+	b := &Package{Name: "b"}
+	g := &DependencyGraph{
+		Root:  a,
+		Nodes: map[string]*Package{"a": a, "b": b},
+		Edges: []*DependencyEdge{
+			{From: a, Kind: KindDepends, Alternatives: []*DependencyAlternative{{Package: b}}},
+			{From: b, Kind: KindDepends, Alternatives: []*DependencyAlternative{{Package: a}}},
+		},
+	}
+
+	if _, err := TopologicalSort(g); err == nil {
+		t.Fatal("expected an error for a cyclic graph, got nil")
+	}
+}
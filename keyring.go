@@ -0,0 +1,258 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultKeyringPath is the directory AddRepositoryKey writes dearmored
+// keyrings to, matching the location apt itself recommends in place of
+// the deprecated apt-key.
+const DefaultKeyringPath = "/etc/apt/keyrings"
+
+// DefaultKeyserver is used by FetchRepositoryKey when no keyserver is
+// explicitly provided.
+const DefaultKeyserver = "hkps://keyserver.ubuntu.com"
+
+// AddRepositoryKey dearmors the ASCII-armored key read from keyMaterial,
+// writes it under DefaultKeyringPath in a file named after the
+// repository's first URI (scheme stripped, "/" replaced by "-"), or after
+// the key's fingerprint when the repository has no URI, and points
+// repo.SignedBy/repo.Options at
+// the resulting file. It requires gpg to be available on PATH. If
+// expectedFingerprint is non-empty, the key is rejected unless its actual
+// fingerprint matches it.
+func AddRepositoryKey(repo *Repository, keyMaterial io.Reader, expectedFingerprint string) error {
+	return AddRepositoryKeyTo(repo, keyMaterial, DefaultKeyringPath, expectedFingerprint)
+}
+
+// AddRepositoryKeyTo behaves like AddRepositoryKey but writes the
+// dearmored keyring under the given directory instead of
+// DefaultKeyringPath.
+func AddRepositoryKeyTo(repo *Repository, keyMaterial io.Reader, keyringPath, expectedFingerprint string) error {
+	if repo == nil {
+		return fmt.Errorf("apt.AddRepositoryKey: Invalid repository")
+	}
+
+	armored, err := ioutil.ReadAll(keyMaterial)
+	if err != nil {
+		return fmt.Errorf("reading key material: %s", err)
+	}
+
+	dearmored, fingerprint, err := dearmorAndFingerprint(armored)
+	if err != nil {
+		return fmt.Errorf("processing key: %s", err)
+	}
+	if expectedFingerprint != "" && !fingerprintsMatch(fingerprint, expectedFingerprint) {
+		return fmt.Errorf("apt.AddRepositoryKey: key fingerprint %s does not match expected %s", fingerprint, expectedFingerprint)
+	}
+
+	if err := os.MkdirAll(keyringPath, 0755); err != nil {
+		return fmt.Errorf("creating %s: %s", keyringPath, err)
+	}
+
+	keyFile := filepath.Join(keyringPath, keyringFileName(repo, fingerprint))
+	if err := ioutil.WriteFile(keyFile, dearmored, 0644); err != nil {
+		return fmt.Errorf("writing %s: %s", keyFile, err)
+	}
+
+	repo.SignedBy = keyFile
+	repo.Options = setKeyValueOption(repo.Options, "signed-by", keyFile)
+	return nil
+}
+
+// RemoveRepositoryKey removes the keyring file previously written by
+// AddRepositoryKey and clears repo.SignedBy.
+func RemoveRepositoryKey(repo *Repository) error {
+	if repo == nil || repo.SignedBy == "" {
+		return fmt.Errorf("apt.RemoveRepositoryKey: Repository has no associated key")
+	}
+	if err := os.Remove(repo.SignedBy); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing %s: %s", repo.SignedBy, err)
+	}
+	repo.Options = removeKeyValueOption(repo.Options, "signed-by")
+	repo.SignedBy = ""
+	return nil
+}
+
+// ListRepositoryKeys returns the fingerprints of every keyring file found
+// under keyringPath (usually DefaultKeyringPath).
+func ListRepositoryKeys(keyringPath string) ([]string, error) {
+	entries, err := ioutil.ReadDir(keyringPath)
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", keyringPath, err)
+	}
+
+	res := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(keyringPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		fingerprint, err := gpgFingerprint(data)
+		if err != nil {
+			continue
+		}
+		res = append(res, fingerprint)
+	}
+	return res, nil
+}
+
+// FetchRepositoryKey fetches the key matching fingerprint from keyserver
+// (DefaultKeyserver if empty) and returns its dearmored bytes, ready to
+// be written to a keyring file or passed to AddRepositoryKey. Keyservers
+// can return a different key than the one asked for (e.g. on a short-ID
+// or fingerprint collision), so the exported key's own fingerprint is
+// checked against fingerprint before it is returned.
+func FetchRepositoryKey(fingerprint, keyserver string) ([]byte, error) {
+	if strings.TrimSpace(fingerprint) == "" {
+		return nil, fmt.Errorf("apt.FetchRepositoryKey: Invalid empty fingerprint")
+	}
+	if keyserver == "" {
+		keyserver = DefaultKeyserver
+	}
+
+	tmpHome, err := ioutil.TempDir("", "go-apt-client-gpg")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary gnupg home: %s", err)
+	}
+	defer os.RemoveAll(tmpHome)
+
+	cmd := exec.Command("gpg", "--homedir", tmpHome, "--no-default-keyring",
+		"--keyserver", keyserver, "--recv-keys", fingerprint)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("fetching key %s from %s: %s - %s", fingerprint, keyserver, err, out)
+	}
+
+	cmd = exec.Command("gpg", "--homedir", tmpHome, "--no-default-keyring",
+		"--export", fingerprint)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("exporting key %s: %s", fingerprint, err)
+	}
+
+	actual, err := gpgFingerprint(out)
+	if err != nil {
+		return nil, fmt.Errorf("verifying fetched key: %s", err)
+	}
+	if !fingerprintsMatch(actual, fingerprint) {
+		return nil, fmt.Errorf("apt.FetchRepositoryKey: keyserver %s returned key %s, expected %s", keyserver, actual, fingerprint)
+	}
+	return out, nil
+}
+
+// AddRepositoryStrict behaves like AddRepository, but refuses to add the
+// repository if it has no associated signing key (i.e. repo.SignedBy is
+// empty). Use it to enforce that every repository added by the caller is
+// signed.
+func AddRepositoryStrict(repo *Repository, configFolderPath string) error {
+	if repo != nil && repo.SignedBy == "" {
+		return fmt.Errorf("apt.AddRepositoryStrict: refusing to add unsigned repository %s", repo.URI)
+	}
+	return AddRepository(repo, configFolderPath)
+}
+
+// dearmorAndFingerprint converts an ASCII-armored key to binary OpenPGP
+// format via gpg --dearmor and returns it alongside its fingerprint.
+func dearmorAndFingerprint(armored []byte) ([]byte, string, error) {
+	cmd := exec.Command("gpg", "--dearmor")
+	cmd.Stdin = bytes.NewReader(armored)
+	dearmored, err := cmd.Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("running gpg --dearmor: %s", err)
+	}
+
+	fingerprint, err := gpgFingerprint(dearmored)
+	if err != nil {
+		return nil, "", err
+	}
+	return dearmored, fingerprint, nil
+}
+
+// gpgFingerprint returns the primary key fingerprint of a (binary or
+// armored) OpenPGP key, as reported by gpg --with-colons.
+func gpgFingerprint(key []byte) (string, error) {
+	cmd := exec.Command("gpg", "--with-colons", "--import-options", "show-only", "--import")
+	cmd.Stdin = bytes.NewReader(key)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("running gpg --with-colons: %s", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) > 9 && fields[0] == "fpr" {
+			return fields[9], nil
+		}
+	}
+	return "", fmt.Errorf("no fingerprint found in gpg output")
+}
+
+// fingerprintsMatch compares two OpenPGP fingerprints ignoring case and
+// the spaces gpg sometimes uses to group them into blocks.
+func fingerprintsMatch(a, b string) bool {
+	return normalizeFingerprint(a) == normalizeFingerprint(b)
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+}
+
+func keyringFileName(repo *Repository, fingerprint string) string {
+	host := repo.URI
+	if len(repo.URIs) > 0 {
+		host = repo.URIs[0]
+	}
+	host = strings.NewReplacer("https://", "", "http://", "", "/", "-").Replace(host)
+	if host == "" {
+		host = fingerprint
+	}
+	return host + ".gpg"
+}
+
+func setKeyValueOption(options, key, value string) string {
+	opts := strings.Fields(removeKeyValueOption(options, key))
+	opts = append(opts, key+"="+value)
+	return strings.Join(opts, " ")
+}
+
+func removeKeyValueOption(options, key string) string {
+	fields := strings.Fields(options)
+	res := []string{}
+	for _, field := range fields {
+		if strings.HasPrefix(field, key+"=") {
+			continue
+		}
+		res = append(res, field)
+	}
+	return strings.Join(res, " ")
+}
@@ -0,0 +1,67 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import "testing"
+
+func TestKeyringFileName(t *testing.T) {
+	repo := &Repository{URI: "https://example.com/debian/"}
+	if got := keyringFileName(repo, "DEADBEEF"); got != "example.com-debian-.gpg" {
+		t.Errorf("unexpected keyring file name: %q", got)
+	}
+
+	virtual := &Repository{}
+	if got := keyringFileName(virtual, "DEADBEEF"); got != "DEADBEEF.gpg" {
+		t.Errorf("expected fallback to fingerprint, got %q", got)
+	}
+}
+
+func TestFingerprintsMatch(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		match bool
+	}{
+		{"ABCD 1234", "abcd1234", true},
+		{"ABCD1234", "ABCD1235", false},
+		{"", "", true},
+	}
+	for _, c := range cases {
+		if got := fingerprintsMatch(c.a, c.b); got != c.match {
+			t.Errorf("fingerprintsMatch(%q, %q) = %v, want %v", c.a, c.b, got, c.match)
+		}
+	}
+}
+
+func TestSetAndRemoveKeyValueOption(t *testing.T) {
+	opts := setKeyValueOption("arch=amd64", "signed-by", "/etc/apt/keyrings/example.gpg")
+	if opts != "arch=amd64 signed-by=/etc/apt/keyrings/example.gpg" {
+		t.Errorf("unexpected options after set: %q", opts)
+	}
+
+	// Setting it again should replace the previous value, not duplicate it.
+	opts = setKeyValueOption(opts, "signed-by", "/etc/apt/keyrings/other.gpg")
+	if opts != "arch=amd64 signed-by=/etc/apt/keyrings/other.gpg" {
+		t.Errorf("unexpected options after replace: %q", opts)
+	}
+
+	opts = removeKeyValueOption(opts, "signed-by")
+	if opts != "arch=amd64" {
+		t.Errorf("unexpected options after remove: %q", opts)
+	}
+}
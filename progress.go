@@ -0,0 +1,247 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ProgressPhase identifies the kind of event APT::Status-Fd reported.
+type ProgressPhase string
+
+// The phases apt-get reports on its status-fd, see APT::Status-Fd in
+// apt.conf(5) and apt-get(8).
+const (
+	PhaseDownloading ProgressPhase = "downloading"
+	PhaseInstalling  ProgressPhase = "installing"
+	PhaseError       ProgressPhase = "error"
+	PhaseConffile    ProgressPhase = "conffile"
+)
+
+// ProgressEvent is a single status update emitted while an apt operation
+// is running.
+type ProgressEvent struct {
+	Phase   ProgressPhase
+	Package string
+	Percent float64
+	Message string
+}
+
+// aptStatusFd is the file descriptor number we tell apt-get to write its
+// machine-readable status stream to, via -o APT::Status-Fd=N.
+const aptStatusFd = 3
+
+// InstallWithContext is the context-aware, progress-reporting equivalent
+// of Install. It returns a channel of ProgressEvent, fed as apt-get
+// reports status, and a wait function that blocks until the operation
+// completes and returns the same (output, err) pair Install would have.
+// Cancelling ctx terminates the underlying apt-get process group.
+func InstallWithContext(ctx context.Context, packs ...*Package) (<-chan ProgressEvent, func() ([]byte, error)) {
+	args := []string{"install", "-y"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return failedProgress(fmt.Errorf("apt.InstallWithContext: Invalid package with empty Name"))
+		}
+		args = append(args, pack.Name)
+	}
+	return runAPTWithProgress(ctx, args)
+}
+
+// UpgradeWithContext is the context-aware, progress-reporting equivalent
+// of Upgrade.
+func UpgradeWithContext(ctx context.Context, packs ...*Package) (<-chan ProgressEvent, func() ([]byte, error)) {
+	args := []string{"upgrade", "-y"}
+	for _, pack := range packs {
+		if pack == nil || pack.Name == "" {
+			return failedProgress(fmt.Errorf("apt.UpgradeWithContext: Invalid package with empty Name"))
+		}
+		args = append(args, pack.Name)
+	}
+	return runAPTWithProgress(ctx, args)
+}
+
+// DistUpgradeWithContext is the context-aware, progress-reporting
+// equivalent of DistUpgrade.
+func DistUpgradeWithContext(ctx context.Context) (<-chan ProgressEvent, func() ([]byte, error)) {
+	return runAPTWithProgress(ctx, []string{"dist-upgrade", "-y"})
+}
+
+// DownloadWithContext is the context-aware, progress-reporting
+// equivalent of Download.
+func DownloadWithContext(ctx context.Context, pack *Package, targetPath string) (<-chan ProgressEvent, func() ([]byte, error)) {
+	if pack == nil || pack.Name == "" {
+		return failedProgress(fmt.Errorf("apt.DownloadWithContext: Invalid package with empty Name"))
+	}
+	if err := os.MkdirAll(path.Join(targetPath, "partial"), 0755); err != nil {
+		return failedProgress(err)
+	}
+	args := []string{"install", "-y", "--reinstall", "--download-only",
+		"-o", "Debug::NoLocking=1",
+		"-o", fmt.Sprintf("Dir::Cache::archives=\"%s\"", targetPath),
+		pack.Name,
+	}
+	return runAPTWithProgress(ctx, args)
+}
+
+// CheckForUpdatesWithContext is the context-aware, progress-reporting
+// equivalent of CheckForUpdates.
+func CheckForUpdatesWithContext(ctx context.Context) (<-chan ProgressEvent, func() ([]byte, error)) {
+	return runAPTWithProgress(ctx, []string{"update", "-q"})
+}
+
+// failedProgress returns an already-closed events channel and a wait
+// function that immediately reports err, for use when an operation can't
+// even be started (e.g. invalid arguments).
+func failedProgress(err error) (<-chan ProgressEvent, func() ([]byte, error)) {
+	events := make(chan ProgressEvent)
+	close(events)
+	return events, func() ([]byte, error) { return nil, err }
+}
+
+// runAPTWithProgress runs apt-get with the given arguments plus
+// APT::Status-Fd, streaming the parsed status lines on the returned
+// channel. The returned wait function blocks until apt-get exits and
+// returns its combined stdout/stderr plus any error; cancelling ctx
+// kills apt-get's whole process group.
+func runAPTWithProgress(ctx context.Context, args []string) (<-chan ProgressEvent, func() ([]byte, error)) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return failedProgress(fmt.Errorf("creating status pipe: %s", err))
+	}
+
+	fullArgs := append(append([]string{}, args...), "-o", fmt.Sprintf("APT::Status-Fd=%d", aptStatusFd))
+	cmd := exec.CommandContext(ctx, "apt-get", fullArgs...)
+	cmd.ExtraFiles = []*os.File{pw}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		pr.Close()
+		return failedProgress(fmt.Errorf("starting apt-get: %s", err))
+	}
+	pw.Close()
+
+	events := make(chan ProgressEvent)
+	go func() {
+		defer pr.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			if ev, ok := parseStatusFdLine(scanner.Text()); ok {
+				events <- ev
+			}
+		}
+	}()
+
+	cancelled := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if cmd.Process != nil {
+				// Negative pid targets the whole process group created by
+				// Setpgid above, so apt-get's helper processes die too.
+				syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			}
+		case <-cancelled:
+		}
+	}()
+
+	wait := func() ([]byte, error) {
+		err := cmd.Wait()
+		close(cancelled)
+		if err == nil {
+			err = ctx.Err()
+		}
+		return output.Bytes(), err
+	}
+
+	return events, wait
+}
+
+// parseStatusFdLine parses one line of apt-get's APT::Status-Fd output,
+// which has the general shape "type:package-or-id:percent:message" (the
+// exact field count depends on type). See apt-get(8), section "status-fd
+// file descriptor".
+func parseStatusFdLine(line string) (ProgressEvent, bool) {
+	parts := strings.SplitN(line, ":", 4)
+	if len(parts) < 2 {
+		return ProgressEvent{}, false
+	}
+
+	ev := ProgressEvent{}
+	switch parts[0] {
+	case "dlstatus":
+		// For dlstatus, parts[1] is the numeric ID of the download item
+		// (its position in the download queue), not a package name, so
+		// Package is left empty; the item being downloaded is only
+		// identifiable from Message.
+		ev.Phase = PhaseDownloading
+	case "pmstatus":
+		ev.Package = strings.TrimSpace(parts[1])
+		ev.Phase = PhaseInstalling
+	case "pmerror":
+		ev.Package = strings.TrimSpace(parts[1])
+		ev.Phase = PhaseError
+	case "pmconffile":
+		ev.Package = strings.TrimSpace(parts[1])
+		ev.Phase = PhaseConffile
+	default:
+		return ProgressEvent{}, false
+	}
+
+	if len(parts) > 2 {
+		if pct, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64); err == nil {
+			ev.Percent = pct
+			if len(parts) > 3 {
+				ev.Message = strings.TrimSpace(parts[3])
+			}
+		} else {
+			// pmerror/pmconffile carry their message starting at this
+			// field instead of a percentage.
+			msg := []string{parts[2]}
+			if len(parts) > 3 {
+				msg = append(msg, parts[3])
+			}
+			ev.Message = strings.TrimSpace(strings.Join(msg, ":"))
+		}
+	}
+	return ev, true
+}
+
+// drainProgress consumes every event on events (discarding them) and
+// returns whatever the wait function reports. It is used by the one-shot
+// functions that only want the final (output, err) pair.
+func drainProgress(events <-chan ProgressEvent, wait func() ([]byte, error)) ([]byte, error) {
+	for range events {
+	}
+	return wait()
+}
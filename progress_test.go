@@ -0,0 +1,97 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import "testing"
+
+func TestParseStatusFdLineDlstatus(t *testing.T) {
+	ev, ok := parseStatusFdLine("dlstatus:7:42.1:Downloading curl 1:7.88.1-10")
+	if !ok {
+		t.Fatalf("expected a parsed event")
+	}
+	if ev.Phase != PhaseDownloading {
+		t.Errorf("expected PhaseDownloading, got %q", ev.Phase)
+	}
+	if ev.Package != "" {
+		t.Errorf("expected empty Package for dlstatus (parts[1] is a queue index, not a name), got %q", ev.Package)
+	}
+	if ev.Percent != 42.1 {
+		t.Errorf("expected Percent 42.1, got %v", ev.Percent)
+	}
+	if ev.Message != "Downloading curl 1:7.88.1-10" {
+		t.Errorf("unexpected Message: %q", ev.Message)
+	}
+}
+
+func TestParseStatusFdLinePmstatus(t *testing.T) {
+	ev, ok := parseStatusFdLine("pmstatus:curl:50:Installing curl")
+	if !ok {
+		t.Fatalf("expected a parsed event")
+	}
+	if ev.Phase != PhaseInstalling {
+		t.Errorf("expected PhaseInstalling, got %q", ev.Phase)
+	}
+	if ev.Package != "curl" {
+		t.Errorf("expected Package curl, got %q", ev.Package)
+	}
+	if ev.Percent != 50 {
+		t.Errorf("expected Percent 50, got %v", ev.Percent)
+	}
+}
+
+func TestParseStatusFdLinePmerror(t *testing.T) {
+	ev, ok := parseStatusFdLine("pmerror:curl:Some error happened")
+	if !ok {
+		t.Fatalf("expected a parsed event")
+	}
+	if ev.Phase != PhaseError {
+		t.Errorf("expected PhaseError, got %q", ev.Phase)
+	}
+	if ev.Package != "curl" {
+		t.Errorf("expected Package curl, got %q", ev.Package)
+	}
+	if ev.Message != "Some error happened" {
+		t.Errorf("unexpected Message: %q", ev.Message)
+	}
+}
+
+func TestParseStatusFdLinePmconffile(t *testing.T) {
+	ev, ok := parseStatusFdLine("pmconffile:/etc/curl/curlrc:'/etc/curl/curlrc' '/etc/curl/curlrc.dpkg-new' 1 1")
+	if !ok {
+		t.Fatalf("expected a parsed event")
+	}
+	if ev.Phase != PhaseConffile {
+		t.Errorf("expected PhaseConffile, got %q", ev.Phase)
+	}
+	if ev.Package != "/etc/curl/curlrc" {
+		t.Errorf("unexpected Package: %q", ev.Package)
+	}
+}
+
+func TestParseStatusFdLineUnknownType(t *testing.T) {
+	if _, ok := parseStatusFdLine("whatever:foo:bar"); ok {
+		t.Fatalf("expected unknown status type to be rejected")
+	}
+}
+
+func TestParseStatusFdLineTooFewFields(t *testing.T) {
+	if _, ok := parseStatusFdLine("dlstatus"); ok {
+		t.Fatalf("expected a line with no ':' to be rejected")
+	}
+}
@@ -52,11 +52,40 @@ type Repository struct {
 	Distribution string
 	Components   string
 	Comment      string
+
+	// Format records which on-disk representation this Repository was
+	// parsed from, and which one AddRepository should write. The zero
+	// value is FormatOneLine, so existing callers are unaffected.
+	Format RepositoryFormat
+
+	// The fields below are populated for Format == FormatDEB822, where a
+	// single stanza may carry more than one type/URI/suite. For
+	// FormatOneLine repositories, SourceRepo/URI/Distribution/Components
+	// above remain the only source of truth, except that AddRepositoryKey
+	// also sets SignedBy (alongside a signed-by= entry in Options), and
+	// AddRepositoryStrict checks SignedBy whatever the format.
+	Types         []string
+	URIs          []string
+	Suites        []string
+	ComponentList []string
+	SignedBy      string
+	Architectures []string
 }
 
 // Equals check if the Repository definition is equivalent to the
 // one provided as parameter
 func (r *Repository) Equals(repo *Repository) bool {
+	if r.Format != repo.Format {
+		return false
+	}
+	if r.Format == FormatDEB822 {
+		return slicesEqual(r.Types, repo.Types) &&
+			slicesEqual(r.URIs, repo.URIs) &&
+			slicesEqual(r.Suites, repo.Suites) &&
+			slicesEqual(r.ComponentList, repo.ComponentList) &&
+			slicesEqual(r.Architectures, repo.Architectures) &&
+			r.SignedBy == repo.SignedBy
+	}
 	if r.Components != repo.Components {
 		return false
 	}
@@ -136,9 +165,12 @@ func parseAPTConfigFile(configPath string) (RepositoryList, error) {
 }
 
 // ParseAPTConfigFolder scans an APT config folder (usually /etc/apt) to
-// get information about configured repositories
+// get information about configured repositories. Both the legacy
+// one-line sources.list format and the DEB822 *.sources format are
+// recognized.
 func ParseAPTConfigFolder(folderPath string) (RepositoryList, error) {
 	sources := []string{filepath.Join(folderPath, "sources.list")}
+	sourcesDEB822 := []string{}
 
 	sourcesFolder := filepath.Join(folderPath, "sources.list.d")
 	list, err := ioutil.ReadDir(sourcesFolder)
@@ -146,8 +178,11 @@ func ParseAPTConfigFolder(folderPath string) (RepositoryList, error) {
 		return nil, fmt.Errorf("Reading %s folder: %s", sourcesFolder, err)
 	}
 	for _, l := range list {
-		if strings.HasSuffix(l.Name(), ".list") {
+		switch {
+		case strings.HasSuffix(l.Name(), ".list"):
 			sources = append(sources, filepath.Join(sourcesFolder, l.Name()))
+		case strings.HasSuffix(l.Name(), ".sources"):
+			sourcesDEB822 = append(sourcesDEB822, filepath.Join(sourcesFolder, l.Name()))
 		}
 	}
 
@@ -159,12 +194,20 @@ func ParseAPTConfigFolder(folderPath string) (RepositoryList, error) {
 		}
 		res = append(res, repos...)
 	}
+	for _, source := range sourcesDEB822 {
+		repos, err := parseDEB822File(source)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing %s: %s", source, err)
+		}
+		res = append(res, repos...)
+	}
 	return res, nil
 }
 
 // AddRepository adds the specified repository to the specified APT
 // config folder (usually /etc/apt). The new repository is saved into
-// a file named "managed.list"
+// a file named "managed.list", unless repo.Format is FormatDEB822, in
+// which case it is appended as a stanza to "managed.sources".
 func AddRepository(repo *Repository, configFolderPath string) error {
 	repos, err := ParseAPTConfigFolder(configFolderPath)
 	if err != nil {
@@ -174,6 +217,14 @@ func AddRepository(repo *Repository, configFolderPath string) error {
 		return fmt.Errorf("The repository is already configured")
 	}
 
+	pre := maybeSnapshot(configFolderPath)
+
+	if repo.Format == FormatDEB822 {
+		err = addRepositoryDEB822(repo, configFolderPath)
+		recordTransaction(ActionAddRepository, nil, pre, configFolderPath, repo.DEB822Stanza(), nil, err)
+		return err
+	}
+
 	// Add to the "managed.list" file
 	managedPath := filepath.Join(configFolderPath, "sources.list.d", "managed.list")
 	f, err := os.OpenFile(managedPath, os.O_APPEND|os.O_WRONLY, 0644)
@@ -185,7 +236,8 @@ func AddRepository(repo *Repository, configFolderPath string) error {
 	}
 	defer f.Close()
 	if _, err = f.WriteString(repo.APTConfigLine() + "\n"); err != nil {
-		return fmt.Errorf("Writing repo data to config file %s: %s", managedPath, err)
+		err = fmt.Errorf("Writing repo data to config file %s: %s", managedPath, err)
 	}
-	return nil
-}
\ No newline at end of file
+	recordTransaction(ActionAddRepository, nil, pre, configFolderPath, repo.APTConfigLine(), nil, err)
+	return err
+}
@@ -0,0 +1,405 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TransactionAction identifies which package-manager operation a
+// Transaction recorded.
+type TransactionAction string
+
+// The actions the transaction log knows how to record and roll back.
+const (
+	ActionInstall       TransactionAction = "install"
+	ActionRemove        TransactionAction = "remove"
+	ActionUpgrade       TransactionAction = "upgrade"
+	ActionAddRepository TransactionAction = "add-repository"
+)
+
+// SystemSnapshot is a point-in-time capture of package selections and
+// repository configuration, used as the pre-state of a Transaction and
+// as the basis for Rollback.
+type SystemSnapshot struct {
+	// Versions maps installed package name to its installed version, as
+	// reported by dpkg.
+	Versions map[string]string
+	// Repositories is the parsed repository configuration.
+	Repositories RepositoryList
+	// RepoFiles holds the raw content of every sources.list/*.list/
+	// *.sources file, keyed by path relative to the config folder, so
+	// Rollback can restore them byte-for-byte.
+	RepoFiles map[string]string
+}
+
+// Transaction is one journaled call to Install, Remove, Upgrade or
+// AddRepository.
+type Transaction struct {
+	ID         string
+	Timestamp  time.Time
+	Action     TransactionAction
+	Packages   []string `json:",omitempty"`
+	ConfigPath string   `json:",omitempty"`
+	Detail     string   `json:",omitempty"`
+	PreState   *SystemSnapshot
+	Output     string
+	Error      string `json:",omitempty"`
+}
+
+// transactionLogPath is where recordTransaction appends journal entries.
+// It is empty by default, which disables recording entirely: Install,
+// Remove, Upgrade and AddRepository behave exactly as before unless a
+// caller opts in with SetTransactionLogPath.
+var transactionLogPath string
+
+// SetTransactionLogPath enables the transaction journal and sets where it
+// is stored. Pass an empty string to disable recording again.
+func SetTransactionLogPath(path string) {
+	transactionLogPath = path
+}
+
+// TransactionLogPath returns the path previously set with
+// SetTransactionLogPath, or "" if recording is disabled.
+func TransactionLogPath() string {
+	return transactionLogPath
+}
+
+// Snapshot captures the current package selections and APT repository
+// configuration under /etc/apt.
+func Snapshot() (*SystemSnapshot, error) {
+	return snapshotAt("/etc/apt")
+}
+
+func snapshotAt(configFolderPath string) (*SystemSnapshot, error) {
+	packs, err := List()
+	if err != nil {
+		return nil, fmt.Errorf("listing installed packages: %s", err)
+	}
+	versions := make(map[string]string, len(packs))
+	for _, p := range packs {
+		if p.Status != "installed" {
+			continue
+		}
+		versions[p.Name] = p.Version
+	}
+
+	repos, err := ParseAPTConfigFolder(configFolderPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing APT config: %s", err)
+	}
+
+	return &SystemSnapshot{
+		Versions:     versions,
+		Repositories: repos,
+		RepoFiles:    captureRepoFiles(configFolderPath),
+	}, nil
+}
+
+// maybeSnapshot returns a SystemSnapshot of configFolderPath, or nil if
+// the transaction log is disabled or the snapshot can't be taken. It is
+// used to capture pre-state without cost when nobody asked for a journal.
+func maybeSnapshot(configFolderPath string) *SystemSnapshot {
+	if transactionLogPath == "" {
+		return nil
+	}
+	snap, err := snapshotAt(configFolderPath)
+	if err != nil {
+		return nil
+	}
+	return snap
+}
+
+func captureRepoFiles(configFolderPath string) map[string]string {
+	files := map[string]string{}
+
+	addFile := func(rel string) {
+		data, err := ioutil.ReadFile(filepath.Join(configFolderPath, rel))
+		if err == nil {
+			files[rel] = string(data)
+		}
+	}
+
+	addFile("sources.list")
+	entries, err := ioutil.ReadDir(filepath.Join(configFolderPath, "sources.list.d"))
+	if err == nil {
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), ".list") || strings.HasSuffix(e.Name(), ".sources") {
+				addFile(filepath.Join("sources.list.d", e.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// recordTransaction appends a Transaction to the journal, if one is
+// configured. Failures to write the journal are not surfaced: they must
+// never cause the package operation itself to be reported as failed.
+func recordTransaction(action TransactionAction, packages []string, pre *SystemSnapshot, configPath, detail string, output []byte, opErr error) {
+	if transactionLogPath == "" {
+		return
+	}
+	tx := &Transaction{
+		ID:         newTransactionID(),
+		Timestamp:  time.Now(),
+		Action:     action,
+		Packages:   packages,
+		ConfigPath: configPath,
+		Detail:     detail,
+		PreState:   pre,
+		Output:     string(output),
+	}
+	if opErr != nil {
+		tx.Error = opErr.Error()
+	}
+	_ = appendTransaction(transactionLogPath, tx)
+}
+
+func appendTransaction(path string, tx *Transaction) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %s", filepath.Dir(path), err)
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("encoding transaction: %s", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %s", path, err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func newTransactionID() string {
+	return fmt.Sprintf("%x", time.Now().UnixNano())
+}
+
+func packageNames(packs []*Package) []string {
+	names := make([]string, 0, len(packs))
+	for _, p := range packs {
+		if p != nil {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ListTransactions returns every Transaction recorded so far, oldest
+// first. It requires a transaction log to have been configured with
+// SetTransactionLogPath.
+func ListTransactions() ([]*Transaction, error) {
+	if transactionLogPath == "" {
+		return nil, fmt.Errorf("apt.ListTransactions: no transaction log configured, call SetTransactionLogPath first")
+	}
+
+	data, err := ioutil.ReadFile(transactionLogPath)
+	if os.IsNotExist(err) {
+		return []*Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", transactionLogPath, err)
+	}
+
+	res := []*Transaction{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// Each line carries a full pre-state snapshot, which can exceed
+	// bufio.Scanner's default 64KB token limit.
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		tx := &Transaction{}
+		if err := json.Unmarshal(line, tx); err != nil {
+			return nil, fmt.Errorf("parsing transaction log: %s", err)
+		}
+		res = append(res, tx)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %s", transactionLogPath, err)
+	}
+	return res, nil
+}
+
+// Rollback computes the inverse of the Transaction identified by txID and
+// issues it: downgrading packages back to their pre-transaction version,
+// reinstalling removed packages, or restoring repository config files,
+// depending on what the transaction did.
+func Rollback(txID string) error {
+	txs, err := ListTransactions()
+	if err != nil {
+		return err
+	}
+
+	var tx *Transaction
+	for _, candidate := range txs {
+		if candidate.ID == txID {
+			tx = candidate
+			break
+		}
+	}
+	if tx == nil {
+		return fmt.Errorf("apt.Rollback: no transaction with ID %q", txID)
+	}
+	if tx.PreState == nil {
+		return fmt.Errorf("apt.Rollback: transaction %q has no recorded pre-state", txID)
+	}
+
+	switch tx.Action {
+	case ActionInstall:
+		return rollbackInstall(tx)
+	case ActionRemove:
+		return rollbackRemove(tx)
+	case ActionUpgrade:
+		return rollbackUpgrade(tx)
+	case ActionAddRepository:
+		return rollbackAddRepository(tx)
+	default:
+		return fmt.Errorf("apt.Rollback: unknown action %q", tx.Action)
+	}
+}
+
+// rollbackInstall removes packages that didn't exist before the install,
+// and downgrades packages that were upgraded as a side effect of it.
+func rollbackInstall(tx *Transaction) error {
+	toRemove, toPin := categorizeInstallRollback(tx)
+	if len(toRemove) > 0 {
+		if _, err := Remove(toRemove...); err != nil {
+			return fmt.Errorf("rolling back install: %s", err)
+		}
+	}
+	if err := installPinned(toPin); err != nil {
+		return fmt.Errorf("rolling back install: %s", err)
+	}
+	return nil
+}
+
+// categorizeInstallRollback splits tx.Packages into packages to remove
+// (those that didn't exist before the install) and packages to pin back
+// to their pre-install version (those that were already installed and
+// got upgraded as a side effect).
+func categorizeInstallRollback(tx *Transaction) (toRemove, toPin []*Package) {
+	for _, name := range tx.Packages {
+		if version, existed := tx.PreState.Versions[name]; existed {
+			toPin = append(toPin, &Package{Name: name, Version: version})
+		} else {
+			toRemove = append(toRemove, &Package{Name: name})
+		}
+	}
+	return toRemove, toPin
+}
+
+// rollbackRemove reinstalls the removed packages, pinned to the version
+// they were at before the removal.
+func rollbackRemove(tx *Transaction) error {
+	packs := make([]*Package, 0, len(tx.Packages))
+	for _, name := range tx.Packages {
+		packs = append(packs, &Package{Name: name, Version: tx.PreState.Versions[name]})
+	}
+	if err := installPinned(packs); err != nil {
+		return fmt.Errorf("rolling back remove: %s", err)
+	}
+	return nil
+}
+
+// rollbackUpgrade downgrades the upgraded packages back to their
+// pre-upgrade version.
+func rollbackUpgrade(tx *Transaction) error {
+	packs := categorizeUpgradeRollback(tx)
+	if err := installPinned(packs); err != nil {
+		return fmt.Errorf("rolling back upgrade: %s", err)
+	}
+	return nil
+}
+
+// categorizeUpgradeRollback returns tx.Packages pinned back to their
+// pre-upgrade version, skipping any package that wasn't installed before
+// the upgrade (it can't be downgraded to a version it never had).
+func categorizeUpgradeRollback(tx *Transaction) []*Package {
+	packs := make([]*Package, 0, len(tx.Packages))
+	for _, name := range tx.Packages {
+		version, existed := tx.PreState.Versions[name]
+		if !existed {
+			continue
+		}
+		packs = append(packs, &Package{Name: name, Version: version})
+	}
+	return packs
+}
+
+// rollbackAddRepository restores the repository config files captured
+// before the repository was added, and removes any managed.list/
+// managed.sources file AddRepository created that didn't previously
+// exist.
+func rollbackAddRepository(tx *Transaction) error {
+	if tx.ConfigPath == "" {
+		return fmt.Errorf("apt.Rollback: transaction has no recorded config path")
+	}
+
+	for rel, content := range tx.PreState.RepoFiles {
+		fullPath := filepath.Join(tx.ConfigPath, rel)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("creating %s: %s", filepath.Dir(fullPath), err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("restoring %s: %s", fullPath, err)
+		}
+	}
+
+	for _, name := range []string{"managed.list", "managed.sources"} {
+		rel := filepath.Join("sources.list.d", name)
+		if _, existed := tx.PreState.RepoFiles[rel]; !existed {
+			os.Remove(filepath.Join(tx.ConfigPath, rel))
+		}
+	}
+	return nil
+}
+
+// installPinned installs packs via apt-get, pinning each to its Version
+// when set (pkg=version syntax) and allowing downgrades, so it can be
+// used to both reinstall a removed package and downgrade an upgraded one.
+func installPinned(packs []*Package) error {
+	if len(packs) == 0 {
+		return nil
+	}
+	args := []string{"install", "-y", "--allow-downgrades"}
+	for _, p := range packs {
+		if p.Version != "" {
+			args = append(args, p.Name+"="+p.Version)
+		} else {
+			args = append(args, p.Name)
+		}
+	}
+	_, err := exec.Command("apt-get", args...).CombinedOutput()
+	return err
+}
@@ -0,0 +1,108 @@
+//
+//  This file is part of go-apt-client library
+//
+//  Copyright (C) 2017  Arduino AG (http://www.arduino.cc/)
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package apt
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendAndListTransactions(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "transactions.jsonl")
+
+	SetTransactionLogPath(logPath)
+	defer SetTransactionLogPath("")
+
+	if TransactionLogPath() != logPath {
+		t.Fatalf("expected TransactionLogPath %q, got %q", logPath, TransactionLogPath())
+	}
+
+	tx1 := &Transaction{ID: "1", Action: ActionInstall, Packages: []string{"curl"}}
+	tx2 := &Transaction{ID: "2", Action: ActionRemove, Packages: []string{"wget"}}
+	if err := appendTransaction(logPath, tx1); err != nil {
+		t.Fatalf("appendTransaction: %s", err)
+	}
+	if err := appendTransaction(logPath, tx2); err != nil {
+		t.Fatalf("appendTransaction: %s", err)
+	}
+
+	txs, err := ListTransactions()
+	if err != nil {
+		t.Fatalf("ListTransactions: %s", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d: %+v", len(txs), txs)
+	}
+	if txs[0].ID != "1" || txs[0].Action != ActionInstall {
+		t.Errorf("unexpected first transaction: %+v", txs[0])
+	}
+	if txs[1].ID != "2" || txs[1].Action != ActionRemove {
+		t.Errorf("unexpected second transaction: %+v", txs[1])
+	}
+}
+
+func TestListTransactionsRequiresLogPath(t *testing.T) {
+	SetTransactionLogPath("")
+	if _, err := ListTransactions(); err == nil {
+		t.Fatal("expected an error when no transaction log is configured")
+	}
+}
+
+func TestCategorizeInstallRollback(t *testing.T) {
+	tx := &Transaction{
+		Action:   ActionInstall,
+		Packages: []string{"new-pack", "existing-pack"},
+		PreState: &SystemSnapshot{
+			Versions: map[string]string{"existing-pack": "1.0"},
+		},
+	}
+
+	toRemove, toPin := categorizeInstallRollback(tx)
+
+	if len(toRemove) != 1 || toRemove[0].Name != "new-pack" {
+		t.Errorf("expected new-pack to be removed, got %+v", toRemove)
+	}
+	if len(toPin) != 1 || toPin[0].Name != "existing-pack" || toPin[0].Version != "1.0" {
+		t.Errorf("expected existing-pack pinned to 1.0, got %+v", toPin)
+	}
+}
+
+func TestCategorizeUpgradeRollbackSkipsPackagesNotPreviouslyInstalled(t *testing.T) {
+	tx := &Transaction{
+		Action:   ActionUpgrade,
+		Packages: []string{"curl", "brand-new-dependency"},
+		PreState: &SystemSnapshot{
+			Versions: map[string]string{"curl": "7.0"},
+		},
+	}
+
+	packs := categorizeUpgradeRollback(tx)
+
+	if len(packs) != 1 || packs[0].Name != "curl" || packs[0].Version != "7.0" {
+		t.Errorf("expected only curl pinned to 7.0, got %+v", packs)
+	}
+}
+
+func TestPackageNamesSkipsNil(t *testing.T) {
+	names := packageNames([]*Package{{Name: "curl"}, nil, {Name: "wget"}})
+	if !slicesEqual(names, []string{"curl", "wget"}) {
+		t.Errorf("unexpected names: %+v", names)
+	}
+}